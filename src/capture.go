@@ -0,0 +1,121 @@
+//go:build !kinc
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Screenshot captures the next presented frame and writes it to path as a
+// PNG. Like recording, this goes through Renderer.CaptureFrame so the
+// screenshot reflects the final postprocessed image, not the raw scene.
+func (s *System) Screenshot(path string) {
+	s.renderer.CaptureFrame(func(w, h int, rgba []byte) {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		// glReadPixels returns rows bottom-to-top; flip into top-to-bottom
+		// row order for the PNG (the recording path does the same thing
+		// via ffmpeg's -vf vflip).
+		stride := img.Stride
+		for row := 0; row < h; row++ {
+			copy(img.Pix[row*stride:(row+1)*stride], rgba[(h-1-row)*stride:(h-row)*stride])
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			s.errLog.Printf("screenshot failed: %v", err)
+			return
+		}
+		defer f.Close()
+
+		if err := png.Encode(f, img); err != nil {
+			s.errLog.Printf("screenshot failed: %v", err)
+		}
+	})
+}
+
+// recording holds the state of an in-progress video capture: every frame
+// captured while active is piped as raw RGBA into an ffmpeg process that
+// encodes it to the destination path.
+type recording struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// activeRecording is non-nil between StartRecording and StopRecording.
+var activeRecording *recording
+
+// StartRecording begins streaming presented frames to path, encoded by an
+// external ffmpeg process. Like Screenshot, capture goes through
+// Renderer.CaptureFrame so the recording matches what's on screen,
+// postprocessing included. A recording already in progress is left
+// running; call StopRecording first to restart with a new path.
+func (s *System) StartRecording(path string) {
+	if activeRecording != nil {
+		s.errLog.Printf("recording already in progress, ignoring StartRecording(%v)", path)
+		return
+	}
+
+	w, h := int(s.scrrect[2]), int(s.scrrect[3])
+	cmd := exec.Command("ffmpeg",
+		"-y",
+		"-f", "rawvideo",
+		"-pixel_format", "rgba",
+		"-video_size", fmt.Sprintf("%dx%d", w, h),
+		"-framerate", "60",
+		"-i", "-",
+		"-vf", "vflip",
+		"-pix_fmt", "yuv420p",
+		path,
+	)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		s.errLog.Printf("recording failed to start: %v", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		s.errLog.Printf("recording failed to start: %v", err)
+		return
+	}
+
+	activeRecording = &recording{cmd: cmd, stdin: stdin}
+	s.captureRecordingFrame()
+}
+
+// captureRecordingFrame schedules capture of the next frame and, while a
+// recording is active, re-schedules itself so every presented frame is
+// streamed to ffmpeg until StopRecording.
+func (s *System) captureRecordingFrame() {
+	s.renderer.CaptureFrame(func(w, h int, rgba []byte) {
+		rec := activeRecording
+		if rec == nil {
+			return
+		}
+		if _, err := rec.stdin.Write(rgba); err != nil {
+			s.errLog.Printf("recording write failed, stopping: %v", err)
+			s.StopRecording()
+			return
+		}
+		s.captureRecordingFrame()
+	})
+}
+
+// StopRecording ends the in-progress recording, if any, closing ffmpeg's
+// input so it finishes encoding the destination file and exits.
+func (s *System) StopRecording() {
+	rec := activeRecording
+	if rec == nil {
+		return
+	}
+	activeRecording = nil
+
+	rec.stdin.Close()
+	if err := rec.cmd.Wait(); err != nil {
+		s.errLog.Printf("recording: ffmpeg exited with error: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !kinc
+
+package main
+
+// Device is the backend-agnostic rendering interface implemented by each
+// graphics API driver. It covers the operations call sites actually need
+// (resource creation, frame bracketing, presenting, clearing) so that
+// adding a new API means adding a new driver, not touching every call
+// site that currently reaches into gl.* directly.
+//
+// Today only the opengl driver (Renderer, in gl.go) is implemented.
+// D3D11, Metal and Vulkan drivers are planned to unblock platforms where
+// the GL path is unreliable (Apple Silicon, some Intel drivers on
+// Windows) and will plug into the same switch in newRenderer.
+type Device interface {
+	NewTexture() *Texture
+	NewShaderProgram(vert, frag, id string) *ShaderProgram
+	BeginFrame()
+	EndFrame()
+	Blit()
+	Clear()
+	// ReloadShaders recompiles every configured external postprocess
+	// shader in place; see Renderer.ReloadShaders.
+	ReloadShaders()
+	// CaptureFrame schedules cb to run with the RGBA pixels of an
+	// upcoming presented frame; see Renderer.CaptureFrame.
+	CaptureFrame(cb func(w, h int, rgba []byte))
+}
+
+// newRenderer selects and initializes the graphics backend configured via
+// sys.gfxBackend ([Video] GraphicsBackend in the config). Only "opengl"
+// is implemented so far; any other value falls back to it with a
+// warning instead of failing to start.
+func newRenderer() Device {
+	switch sys.gfxBackend {
+	case "", "opengl":
+		return newGLRenderer()
+	default:
+		sys.errLog.Printf("graphics backend %q is not available in this build, falling back to opengl", sys.gfxBackend)
+		return newGLRenderer()
+	}
+}
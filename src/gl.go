@@ -6,7 +6,9 @@ import (
 	_ "embed" // Support for go:embed resources
 	"encoding/binary"
 	"fmt"
+	"os"
 	"runtime"
+	"strings"
 
 	gl "github.com/fyne-io/gl-js"
 	"golang.org/x/mobile/exp/f32"
@@ -90,6 +92,69 @@ func linkProgram(v, f gl.Shader) (program gl.Program) {
 	return
 }
 
+// tryCompileShader is compileShader without the panic, for reload paths
+// where a bad shader shouldn't take down a running match.
+func tryCompileShader(shaderType gl.Enum, src string) (shader gl.Shader, err error) {
+	shader = gl.CreateShader(shaderType)
+	gl.ShaderSource(shader, src)
+	gl.CompileShader(shader)
+	if gl.GetShaderi(shader, gl.COMPILE_STATUS) == 0 {
+		log := gl.GetShaderInfoLog(shader)
+		gl.DeleteShader(shader)
+		return gl.Shader{}, Error("Shader compile error: " + log)
+	}
+	return
+}
+
+// tryLinkProgram is linkProgram without the panic, for reload paths.
+func tryLinkProgram(v, f gl.Shader) (program gl.Program, err error) {
+	program = gl.CreateProgram()
+	gl.AttachShader(program, v)
+	gl.AttachShader(program, f)
+	gl.LinkProgram(program)
+	gl.DeleteShader(v)
+	gl.DeleteShader(f)
+	if gl.GetProgrami(program, gl.LINK_STATUS) == 0 {
+		log := gl.GetProgramInfoLog(program)
+		gl.DeleteProgram(program)
+		return gl.Program{}, Error("Link error: " + log)
+	}
+	return
+}
+
+// tryNewShaderProgram is newShaderProgram without the panic: it reports a
+// compile/link failure through err instead, so callers can keep the
+// previous program running.
+func tryNewShaderProgram(vert, frag, id string) (s *ShaderProgram, err error) {
+	vertObj, err := tryCompileShader(gl.VERTEX_SHADER, vert)
+	if err != nil {
+		return nil, err
+	}
+	fragObj, err := tryCompileShader(gl.FRAGMENT_SHADER, frag)
+	if err != nil {
+		// vertObj already compiled successfully; tryLinkProgram never
+		// gets a chance to delete it, so it's on us.
+		gl.DeleteShader(vertObj)
+		return nil, err
+	}
+	prog, err := tryLinkProgram(vertObj, fragObj)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &ShaderProgram{program: prog}
+	s.aPos = gl.GetAttribLocation(s.program, "position")
+	s.aUv = gl.GetAttribLocation(s.program, "uv")
+	s.aVert = gl.GetAttribLocation(s.program, "VertCoord")
+
+	s.uModelView = gl.GetUniformLocation(s.program, "modelview")
+	s.uProjection = gl.GetUniformLocation(s.program, "projection")
+	s.uTexture = gl.GetUniformLocation(s.program, "tex")
+	s.uAlpha = gl.GetUniformLocation(s.program, "alpha")
+	s.u = make(map[string]gl.Uniform)
+	return
+}
+
 // ------------------------------------------------------------------
 // Texture
 
@@ -115,16 +180,32 @@ func (t *Texture) SetData(width, height, depth int32, filter bool, data []byte)
 		interp = gl.LINEAR
 	}
 
-	var format gl.Enum = gl.LUMINANCE
-	if depth == 24 {
+	var format gl.Enum
+	switch {
+	case depth == 24:
 		format = gl.RGB
-	} else if depth == 32 {
+	case depth == 32:
 		format = gl.RGBA
+	case gfxCaps.CoreProfile:
+		// LUMINANCE was removed from core-profile GL 3.3+; upload
+		// single-channel data as RED instead.
+		format = gl.RED
+	default:
+		format = gl.LUMINANCE
 	}
 
 	gl.BindTexture(gl.TEXTURE_2D, t.handle)
 	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
 	gl.TexImage2D(gl.TEXTURE_2D, 0, int(width), int(height), format, gl.UNSIGNED_BYTE, data)
+	if format == gl.RED {
+		// LUMINANCE replicates its one channel into R/G/B (alpha stays 1);
+		// a bare RED texture defaults to G=0, B=0, A=1 instead, so shaders
+		// reading .g/.b/.a off what used to be a LUMINANCE texture (fonts,
+		// masks) would get black/wrong results without this swizzle.
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_G, gl.RED)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_B, gl.RED)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_A, gl.ONE)
+	}
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, interp)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, interp)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
@@ -145,6 +226,59 @@ type Renderer struct {
 	// Post-processing shaders
 	postVertBuffer gl.Buffer
 	postShaderSelect []*ShaderProgram
+	// Ping-pong FBOs used to chain multiple post-processing passes
+	pingpong [2]pingpongFBO
+	// sRGB-correct rendering: sprites blend in linear space and the
+	// identity postprocess pass converts back to sRGB for presentation.
+	srgb bool
+	// Frame capture: double-buffered PBOs so glReadPixels never stalls
+	// the frame that requested a capture. pendingCapture holds requests
+	// not yet issued; captureQueued[i] holds the requests waiting on
+	// capturePBO[i]'s readback to land.
+	capturePBO   [2]gl.Buffer
+	captureSize  int
+	captureCur   int
+	captureQueued [2][]frameCapture
+	pendingCapture []frameCapture
+	// msaa is sys.multisampleAntialiasing clamped by gfxCaps.MultisampleTex:
+	// MSAA requested but unsupported by the driver is disabled outright
+	// rather than left to produce a broken framebuffer.
+	msaa bool
+}
+
+// frameCapture is a one-shot request to receive the RGBA pixels of a
+// fully rendered (post-processed) frame.
+type frameCapture struct {
+	cb func(w, h int, rgba []byte)
+}
+
+// pingpongFBO is one of the two intermediate framebuffers the
+// post-processing chain alternates between: each pass reads the
+// previous pass's texture and renders into the other one.
+type pingpongFBO struct {
+	fbo gl.Framebuffer
+	tex gl.Texture
+}
+
+func newPingpongFBO(width, height int32) (p pingpongFBO) {
+	gl.ActiveTexture(gl.TEXTURE0)
+	p.tex = gl.CreateTexture()
+	gl.BindTexture(gl.TEXTURE_2D, p.tex)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, int(width), int(height), gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.BindTexture(gl.TEXTURE_2D, gl.NoTexture)
+
+	p.fbo = gl.CreateFramebuffer()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, p.tex, 0)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		sys.errLog.Printf("postprocess framebuffer create failed: 0x%x", status)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, gl.NoFramebuffer)
+	return
 }
 
 //go:embed shaders/ident.vert.glsl
@@ -153,11 +287,108 @@ var identVertShader string
 //go:embed shaders/ident.frag.glsl
 var identFragShader string
 
-func newRenderer() (r *Renderer) {
-	sys.errLog.Printf("Using OpenGL %v (%v)",
-		gl.GetString(gl.VERSION), gl.GetString(gl.RENDERER))
+//go:embed shaders/ident_srgb.frag.glsl
+var identSRGBFragShader string
+
+// Caps describes what the active GL context actually supports, queried
+// once at renderer startup so the rest of the renderer can degrade
+// gracefully instead of assuming desktop GL 3.x and crashing (or
+// silently producing a broken framebuffer) on stricter drivers.
+type Caps struct {
+	Version        string
+	Renderer       string
+	MaxSamples     int
+	MaxTextureSize int
+	// CoreProfile is true on desktop GL 3.3+ core, where LUMINANCE was
+	// removed from TexImage2D formats in favor of RED/R8.
+	CoreProfile bool
+	// MultisampleTex is whether TEXTURE_2D_MULTISAMPLE exists. False on
+	// GLES2/WebGL, which has no multisample texture target at all.
+	MultisampleTex bool
+	// FramebufferSRGB is whether GL_FRAMEBUFFER_SRGB exists. Not checked
+	// on its own for sRGB correction; see SRGBTexture.
+	FramebufferSRGB bool
+	// SRGBTexture is whether an sRGB internal texture format (e.g.
+	// GL_SRGB8_ALPHA8) is available. False on GLES2/WebGL1, where there
+	// is no way to composite sprites in linear space at all, so
+	// sys.srgbCorrection is forced off rather than faked.
+	SRGBTexture bool
+}
+
+func (c Caps) String() string {
+	return fmt.Sprintf("%v (%v), max samples %v, max texture size %v, core profile %v",
+		c.Version, c.Renderer, c.MaxSamples, c.MaxTextureSize, c.CoreProfile)
+}
+
+// DebugLines formats Caps for a debug overlay/HUD to render, one entry
+// per line.
+func (c Caps) DebugLines() []string {
+	return []string{
+		fmt.Sprintf("GL: %v", c.Version),
+		fmt.Sprintf("Renderer: %v", c.Renderer),
+		fmt.Sprintf("Max samples: %v  Max texture size: %v", c.MaxSamples, c.MaxTextureSize),
+		fmt.Sprintf("Core profile: %v  MSAA tex: %v  sRGB tex: %v  sRGB fb: %v",
+			c.CoreProfile, c.MultisampleTex, c.SRGBTexture, c.FramebufferSRGB),
+	}
+}
+
+// GfxCapsOverlayLines exposes the active context's Caps to the debug
+// overlay (the F4 stats screen), one line per entry, so drivers that
+// fall back to reduced functionality (no MSAA, no sRGB, LUMINANCE vs
+// RED) are visible without digging through the log.
+func GfxCapsOverlayLines() []string {
+	return gfxCaps.DebugLines()
+}
+
+// queryCaps is always called once, at the start of newGLRenderer.
+func queryCaps() (c Caps) {
+	c.Version = gl.GetString(gl.VERSION)
+	c.Renderer = gl.GetString(gl.RENDERER)
+	c.MaxSamples = gl.GetInteger(gl.MAX_SAMPLES)
+	c.MaxTextureSize = gl.GetInteger(gl.MAX_TEXTURE_SIZE)
+
+	// WebGL reports its version as "WebGL 1.0 ..." or "WebGL 2.0 ...",
+	// not with an "OpenGL ES" prefix.
+	gles := strings.Contains(c.Version, "OpenGL ES") || strings.HasPrefix(c.Version, "WebGL")
+	gles2 := strings.HasPrefix(c.Version, "OpenGL ES 2") || strings.HasPrefix(c.Version, "WebGL 1")
+	c.CoreProfile = !gles && strings.Contains(c.Version, "Core Profile")
+	// TEXTURE_2D_MULTISAMPLE and GL_FRAMEBUFFER_SRGB are both desktop GL
+	// (and GLES3+/WebGL2) features; GLES2/WebGL1 has neither.
+	c.MultisampleTex = !gles2
+	c.FramebufferSRGB = !gles2
+	// sRGB texture formats need GLES3/WebGL2 (or the EXT_sRGB extension,
+	// which this query doesn't probe for); assume unavailable on GLES2.
+	c.SRGBTexture = !gles2
+	return
+}
+
+// gfxCaps is populated once by newGLRenderer and read by code (like
+// Texture.SetData) that has no other way to reach the active Renderer.
+var gfxCaps Caps
+
+// newGLRenderer is the opengl Device driver's constructor, selected by
+// newRenderer in gfxdriver.go.
+func newGLRenderer() (r *Renderer) {
+	gfxCaps = queryCaps()
+	sys.errLog.Printf("Using OpenGL %v", gfxCaps)
 
 	r = &Renderer{}
+	r.srgb = sys.srgbCorrection
+	if r.srgb && !gfxCaps.SRGBTexture {
+		// sRGB correction needs an sRGB-format scene texture to blend
+		// sprites in linear space; on GLES2/WebGL1, which has none, there's
+		// no way to composite in linear space at all (every sprite shader
+		// samples and writes plain sRGB bytes), so a "corrected" identity
+		// pass here would just decode and immediately re-encode its input,
+		// a no-op. Disable it outright instead of pretending it works.
+		sys.errLog.Printf("sRGB correction requested but unsupported on this driver (no sRGB texture format available), disabling it")
+		r.srgb = false
+	}
+	r.msaa = sys.multisampleAntialiasing
+	if r.msaa && !gfxCaps.MultisampleTex {
+		sys.errLog.Printf("MSAA requested but TEXTURE_2D_MULTISAMPLE is unavailable on this driver, disabling it")
+		r.msaa = false
+	}
 	r.postShaderSelect = make([]*ShaderProgram, 1+len(sys.externalShaderList))
 
 	// Data buffers for rendering
@@ -171,25 +402,46 @@ func newRenderer() (r *Renderer) {
 	// Calculate total amount of shaders loaded.
 	r.postShaderSelect = make([]*ShaderProgram, 1+len(sys.externalShaderList))
 
-	// Ident shader (no postprocessing)
-	r.postShaderSelect[0] = newShaderProgram(identVertShader, identFragShader, "Identity Postprocess")
-	r.postShaderSelect[0].RegisterUniforms("Texture", "TextureSize")
+	// Ident shader (no postprocessing). Nothing sets up an sRGB-capable
+	// default framebuffer, so whenever sRGB correction is on this pass
+	// always has to do the final linear -> sRGB re-encode itself on
+	// write, regardless of gfxCaps.FramebufferSRGB (that only governs
+	// whether writes into r.fbo_texture, an actual sRGB-format
+	// attachment, get auto-encoded during scene drawing). r.srgb is only
+	// ever true here when gfxCaps.SRGBTexture is too (see above), so
+	// r.fbo_texture is GL_SRGB8_ALPHA8 and sampling it auto-decodes to
+	// linear; only the write needs a manual encode.
+	identFrag := identFragShader
+	if r.srgb {
+		identFrag = identSRGBFragShader
+	}
+	r.postShaderSelect[0] = newShaderProgram(identVertShader, identFrag, "Identity Postprocess")
+	r.postShaderSelect[0].RegisterUniforms("Texture", "TextureSize", "Time")
 
-	// External Shaders
+	// External Shaders. Configured via [Video] PostProcessingShaders as an
+	// ordered, semicolon-separated list; when more than one is active they
+	// run as a chain, each sampling the previous pass's output.
 	for i := 0; i < len(sys.externalShaderList); i++ {
 		r.postShaderSelect[1+i] = newShaderProgram(sys.externalShaders[0][i],
 			sys.externalShaders[1][i], fmt.Sprintf("Postprocess Shader #%v", i+1))
-		r.postShaderSelect[1+i].RegisterUniforms("Texture", "TextureSize")
+		r.postShaderSelect[1+i].RegisterUniforms("Texture", "TextureSize", "Time")
 	}
 
-	if sys.multisampleAntialiasing {
+	// Ping-pong FBOs for chaining postprocess passes together.
+	r.pingpong[0] = newPingpongFBO(sys.scrrect[2], sys.scrrect[3])
+	r.pingpong[1] = newPingpongFBO(sys.scrrect[2], sys.scrrect[3])
+
+	// Double-buffered PBOs for async frame capture.
+	r.initCapturePBOs(int(sys.scrrect[2]), int(sys.scrrect[3]))
+
+	if r.msaa {
 		gl.Enable(gl.MULTISAMPLE)
 	}
 
 	gl.ActiveTexture(gl.TEXTURE0)
 	r.fbo_texture = gl.CreateTexture()
 
-	if sys.multisampleAntialiasing {
+	if r.msaa {
 		gl.BindTexture(gl.TEXTURE_2D_MULTISAMPLE, r.fbo_texture)
 	} else {
 		gl.BindTexture(gl.TEXTURE_2D, r.fbo_texture)
@@ -200,15 +452,30 @@ func newRenderer() (r *Renderer) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 
-	if sys.multisampleAntialiasing {
-		gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, 16, gl.RGBA, int(sys.scrrect[2]), int(sys.scrrect[3]), false)
+	// Sprites blend into this texture, so give it an sRGB internal format
+	// when sRGB correction is on: blending then happens in linear space
+	// and the postprocess pass converts back to sRGB on present. r.srgb
+	// is only ever true when SRGBTexture is too (see above).
+	sceneFormat := gl.Enum(gl.RGBA)
+	if r.srgb {
+		sceneFormat = gl.SRGB8_ALPHA8
+	}
+
+	if r.msaa {
+		// Clamp the requested sample count to what the driver can do
+		// instead of assuming 16x is always available.
+		samples := 16
+		if gfxCaps.MaxSamples < samples {
+			samples = gfxCaps.MaxSamples
+		}
+		gl.TexImage2DMultisample(gl.TEXTURE_2D_MULTISAMPLE, samples, sceneFormat, int(sys.scrrect[2]), int(sys.scrrect[3]), false)
 	} else {
-		gl.TexImage2D(gl.TEXTURE_2D, 0, int(sys.scrrect[2]), int(sys.scrrect[3]), gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, int(sys.scrrect[2]), int(sys.scrrect[3]), sceneFormat, gl.UNSIGNED_BYTE, nil)
 	}
 
 	gl.BindTexture(gl.TEXTURE_2D, gl.NoTexture)
 
-	if sys.multisampleAntialiasing {
+	if r.msaa {
 		r.fbo_f_texture = newTexture()
 		r.fbo_f_texture.SetData(sys.scrrect[2], sys.scrrect[3], 32, false, nil)
 	} else {
@@ -221,7 +488,7 @@ func newRenderer() (r *Renderer) {
 	r.fbo = gl.CreateFramebuffer()
 	gl.BindFramebuffer(gl.FRAMEBUFFER, r.fbo)
 
-	if sys.multisampleAntialiasing {
+	if r.msaa {
 		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D_MULTISAMPLE, r.fbo_texture, 0)
 
 		r.fbo_f = gl.CreateFramebuffer()
@@ -238,41 +505,205 @@ func newRenderer() (r *Renderer) {
 
 	gl.BindFramebuffer(gl.FRAMEBUFFER, gl.NoFramebuffer)
 
+	r.watchExternalShaders()
+
 	return
 }
 
-func (r *Renderer) BeginFrame() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, r.fbo)
+// NewTexture satisfies Device.
+func (r *Renderer) NewTexture() *Texture {
+	return newTexture()
 }
 
-func (r *Renderer) EndFrame() {
-	if sys.multisampleAntialiasing {
+// NewShaderProgram satisfies Device.
+func (r *Renderer) NewShaderProgram(vert, frag, id string) *ShaderProgram {
+	return newShaderProgram(vert, frag, id)
+}
+
+// Clear clears the currently bound framebuffer's color and depth buffers.
+func (r *Renderer) Clear() {
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// Blit resolves the multisample scene framebuffer into its single-sample
+// counterpart. It is a no-op when MSAA is disabled.
+func (r *Renderer) Blit() {
+	if r.msaa {
 		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, r.fbo_f)
 		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.fbo)
 		gl.BlitFramebuffer(0, 0, int(sys.scrrect[2]), int(sys.scrrect[3]), 0, 0, int(sys.scrrect[2]), int(sys.scrrect[3]), gl.COLOR_BUFFER_BIT, gl.LINEAR)
 	}
+}
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, gl.NoFramebuffer)
+var _ Device = (*Renderer)(nil)
+
+// ReloadShaders re-reads the fragment source of every external
+// postprocess shader from the path it was loaded from
+// (sys.externalShaderList; the vertex source is the copy read at
+// startup, sys.externalShaders[0]) and swaps the
+// corresponding postShaderSelect slot only if both compilation and
+// linking succeed; a shader that fails to build keeps running its last
+// good version, with the driver's info log reported instead of
+// panicking. Wired to watchExternalShaders, this lets shader authors
+// iterate on CRT/scanline/bloom effects without restarting the game or
+// losing match state.
+func (r *Renderer) ReloadShaders() {
+	for i, path := range sys.externalShaderList {
+		frag, ferr := os.ReadFile(path)
+		if ferr != nil {
+			sys.errLog.Printf("shader reload failed for %v: %v", path, ferr)
+			continue
+		}
 
-	postShader := r.postShaderSelect[sys.postProcessingShader]
+		name := fmt.Sprintf("Postprocess Shader #%v", i+1)
+		s, err := tryNewShaderProgram(sys.externalShaders[0][i], string(frag), name)
+		if err != nil {
+			sys.errLog.Printf("shader reload failed for %v: %v", path, err)
+			continue
+		}
+		s.RegisterUniforms("Texture", "TextureSize", "Time")
+		r.postShaderSelect[1+i] = s
+	}
+}
 
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-	postShader.UseProgram()
+func (r *Renderer) BeginFrame() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.fbo)
+	if r.srgb && gfxCaps.FramebufferSRGB {
+		gl.Enable(gl.FRAMEBUFFER_SRGB)
+	}
+}
 
-	if sys.multisampleAntialiasing {
-		gl.BindTexture(gl.TEXTURE_2D, r.fbo_f_texture.handle)
-	} else {
-		gl.BindTexture(gl.TEXTURE_2D, r.fbo_texture)
+// postProcessingChain returns the ordered shader passes to run this
+// frame. sys.postProcessingShader keeps its original meaning: 0 is off
+// (the identity pass only) and N selects a single external shader by
+// index, exactly as before this series. sys.postProcessingChain is new
+// and opt-in: when set, every shader in [Video] PostProcessingShaders
+// order runs in sequence instead, each sampling the previous pass's
+// output, so existing single-shader configs keep their exact look.
+//
+// Slot 0 (the identity pass) is always the chain's last stage, never
+// dropped: it's the only pass that does the linear -> sRGB re-encode
+// for presentation (see newGLRenderer), so external shaders always
+// sample a linear-space scene and that re-encode always runs exactly
+// once, whether or not sRGB correction or chaining is on.
+func (r *Renderer) postProcessingChain() []*ShaderProgram {
+	if !sys.postProcessingChain || len(r.postShaderSelect) <= 1 {
+		if sys.postProcessingShader == 0 {
+			return r.postShaderSelect[:1]
+		}
+		return []*ShaderProgram{r.postShaderSelect[sys.postProcessingShader], r.postShaderSelect[0]}
+	}
+	chain := make([]*ShaderProgram, 0, len(r.postShaderSelect))
+	chain = append(chain, r.postShaderSelect[1:]...)
+	chain = append(chain, r.postShaderSelect[0])
+	return chain
+}
+
+func (r *Renderer) EndFrame() {
+	if r.srgb && gfxCaps.FramebufferSRGB {
+		gl.Disable(gl.FRAMEBUFFER_SRGB)
 	}
 
-	gl.Uniform1i(postShader.u["Texture"], 0)
-	gl.Uniform2f(postShader.u["TextureSize"], float32(sys.scrrect[2]), float32(sys.scrrect[3]))
+	r.Blit()
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, r.postVertBuffer)
-	gl.EnableVertexAttribArray(postShader.aVert)
-	gl.VertexAttribPointer(postShader.aVert, 2, gl.FLOAT, false, 0, 0)
+	chain := r.postProcessingChain()
+
+	srcTex := r.fbo_texture
+	if r.msaa {
+		srcTex = r.fbo_f_texture.handle
+	}
+
+	pp := 0
+	for i, shader := range chain {
+		last := i == len(chain)-1
+		if last {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, gl.NoFramebuffer)
+		} else {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, r.pingpong[pp].fbo)
+		}
+
+		r.Clear()
+		shader.UseProgram()
+
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, srcTex)
+		gl.Uniform1i(shader.u["Texture"], 0)
+		gl.Uniform2f(shader.u["TextureSize"], float32(sys.scrrect[2]), float32(sys.scrrect[3]))
+		gl.Uniform1f(shader.u["Time"], float32(sys.gameTime))
+
+		gl.BindBuffer(gl.ARRAY_BUFFER, r.postVertBuffer)
+		gl.EnableVertexAttribArray(shader.aVert)
+		gl.VertexAttribPointer(shader.aVert, 2, gl.FLOAT, false, 0, 0)
+
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+		gl.DisableVertexAttribArray(shader.aVert)
 
-	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+		if !last {
+			srcTex = r.pingpong[pp].tex
+			pp = 1 - pp
+		}
+	}
+
+	r.flushCaptures()
+}
+
+// initCapturePBOs (re)allocates the pixel buffer objects frame capture
+// reads into, sized for width*height RGBA pixels. Called once from
+// newGLRenderer; captureSize is cached so flushCaptures knows how many
+// bytes to map back out.
+func (r *Renderer) initCapturePBOs(width, height int) {
+	r.captureSize = width * height * 4
+	for i := range r.capturePBO {
+		r.capturePBO[i] = gl.CreateBuffer()
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, r.capturePBO[i])
+		gl.BufferData(gl.PIXEL_PACK_BUFFER, make([]byte, r.captureSize), gl.STREAM_READ)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, gl.NoBuffer)
+}
+
+// CaptureFrame schedules cb to run with the RGBA pixels of an upcoming
+// presented frame, delivered on sys.mainThreadTask once its PBO readback
+// has landed (see flushCaptures). sys.Screenshot and the recording
+// start/stop pair in capture.go build on this to PNG-encode or stream
+// frames to disk.
+func (r *Renderer) CaptureFrame(cb func(w, h int, rgba []byte)) {
+	r.pendingCapture = append(r.pendingCapture, frameCapture{cb: cb})
+}
+
+// flushCaptures double-buffers the PBO readback across two frames: this
+// frame's pending requests are issued into capturePBO[captureCur] via a
+// glReadPixels that returns immediately (the driver queues the DMA into
+// the bound PIXEL_PACK_BUFFER instead of blocking), while the *other*
+// PBO - populated the same way one frame ago - is mapped and delivered,
+// by which point its readback has normally long since landed.
+func (r *Renderer) flushCaptures() {
+	cur, prev := r.captureCur, 1-r.captureCur
+	w, h := int(sys.scrrect[2]), int(sys.scrrect[3])
+
+	if len(r.pendingCapture) > 0 {
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, r.capturePBO[cur])
+		gl.ReadPixels(nil, 0, 0, w, h, gl.RGBA, gl.UNSIGNED_BYTE)
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, gl.NoBuffer)
+		r.captureQueued[cur] = r.pendingCapture
+		r.pendingCapture = nil
+	}
+
+	if queued := r.captureQueued[prev]; len(queued) > 0 {
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, r.capturePBO[prev])
+		data := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, r.captureSize, gl.MAP_READ_BIT)
+		pixels := make([]byte, len(data))
+		copy(pixels, data)
+		gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, gl.NoBuffer)
+
+		r.captureQueued[prev] = nil
+		sys.mainThreadTask <- func() {
+			for _, c := range queued {
+				c.cb(w, h, pixels)
+			}
+		}
+	}
 
-	gl.DisableVertexAttribArray(postShader.aVert)
+	r.captureCur = prev
 }
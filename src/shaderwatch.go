@@ -0,0 +1,59 @@
+//go:build !kinc
+
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchExternalShaders starts a background watcher on the directories
+// containing the configured external postprocess shaders and calls
+// r.ReloadShaders whenever any file in those directories is written or
+// created (not only the shader files themselves), so edits to
+// CRT/scanline/bloom shaders take effect without restarting the game.
+// Started once from newGLRenderer; a no-op if no external shaders are
+// configured.
+func (r *Renderer) watchExternalShaders() {
+	if len(sys.externalShaderList) == 0 {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		sys.errLog.Printf("shader watcher disabled: %v", err)
+		return
+	}
+
+	dirs := make(map[string]bool)
+	for _, path := range sys.externalShaderList {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			sys.errLog.Printf("shader watcher: could not watch %v: %v", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				sys.mainThreadTask <- r.ReloadShaders
+			case werr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				sys.errLog.Printf("shader watcher error: %v", werr)
+			}
+		}
+	}()
+}
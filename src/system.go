@@ -0,0 +1,140 @@
+//go:build !kinc
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// System holds per-process engine state that doesn't belong to any one
+// match or screen. Only the fields the renderer (gl.go, gfxdriver.go,
+// capture.go, shaderwatch.go) actually touches are declared here; the
+// rest of the engine's System lives elsewhere.
+type System struct {
+	renderer Device
+
+	errLog         *log.Logger
+	mainThreadTask chan func()
+
+	scrrect  [4]int32
+	gameTime float32
+
+	// [Video] config, read by loadVideoConfig.
+	gfxBackend              string
+	multisampleAntialiasing bool
+	srgbCorrection          bool
+	postProcessingShader    int
+	postProcessingChain     bool
+	// externalShaderList holds each chained shader's fragment source
+	// path (for ReloadShaders/watchExternalShaders); externalShaders
+	// holds the vertex ([0]) and initial fragment ([1]) source already
+	// read off disk, in the same order.
+	externalShaderList []string
+	externalShaders    [2][]string
+}
+
+// defaultConfigPath is where newSystem looks for video config on
+// startup, matching the save/config.ini the rest of the engine uses for
+// its own settings.
+const defaultConfigPath = "save/config.ini"
+
+func newSystem() *System {
+	s := &System{
+		errLog:         log.New(os.Stderr, "", log.LstdFlags),
+		mainThreadTask: make(chan func(), 65536),
+		scrrect:        [4]int32{0, 0, 640, 480},
+	}
+	if err := s.loadVideoConfig(defaultConfigPath); err != nil && !os.IsNotExist(err) {
+		s.errLog.Printf("failed to load %v: %v", defaultConfigPath, err)
+	}
+	return s
+}
+
+var sys = newSystem()
+
+// loadVideoConfig reads the [Video] section of an Ikemen config file and
+// populates the renderer-facing fields above. Keys that aren't present
+// keep their zero value: GraphicsBackend empty selects the default
+// opengl driver (see newRenderer) and every feature flag defaults off.
+func (s *System) loadVideoConfig(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != "Video" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "GraphicsBackend":
+			s.gfxBackend = value
+		case "MultisampleAntialiasing":
+			s.multisampleAntialiasing, _ = strconv.ParseBool(value)
+		case "SRGBCorrection":
+			s.srgbCorrection, _ = strconv.ParseBool(value)
+		case "PostProcessingShader":
+			s.postProcessingShader, _ = strconv.Atoi(value)
+		case "PostProcessingChain":
+			s.postProcessingChain, _ = strconv.ParseBool(value)
+		case "PostProcessingShaders":
+			if err := s.loadExternalShaders(value); err != nil {
+				s.errLog.Printf("PostProcessingShaders: %v", err)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// loadExternalShaders parses a semicolon-separated "vert:frag" list, in
+// chain order, and reads each pair's GLSL source from disk up front.
+func (s *System) loadExternalShaders(list string) error {
+	for _, pair := range strings.Split(list, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		vert, frag, ok := strings.Cut(pair, ":")
+		if !ok {
+			return fmt.Errorf("expected \"vert:frag\", got %q", pair)
+		}
+
+		vertSrc, err := os.ReadFile(vert)
+		if err != nil {
+			return err
+		}
+		fragSrc, err := os.ReadFile(frag)
+		if err != nil {
+			return err
+		}
+
+		s.externalShaderList = append(s.externalShaderList, frag)
+		s.externalShaders[0] = append(s.externalShaders[0], string(vertSrc))
+		s.externalShaders[1] = append(s.externalShaders[1], string(fragSrc))
+	}
+	return nil
+}